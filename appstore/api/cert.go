@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// appleWWDRStoreKitEKU is the OID Apple documents for the WWDR StoreKit signing certificate's
+// extended key usage.
+// Per doc: https://developer.apple.com/library/archive/documentation/NetworkingInternet/Conceptual/StoreKitGuide
+var appleWWDRStoreKitEKU = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 11, 1}
+
+// Cert verifies the x5c certificate chain embedded in Apple's signed JWS payloads against a
+// pinned trust store, rather than trusting whatever root the chain itself supplies.
+type Cert struct {
+	// RootCertificates are additional PEM-encoded roots trusted alongside AppleRootCAG3PEM.
+	RootCertificates [][]byte
+}
+
+type jwsHeader struct {
+	Alg string   `json:"alg"`
+	X5C []string `json:"x5c"`
+}
+
+func (c *Cert) extractCertByIndex(jwsRepresentation string, index int) ([]byte, error) {
+	if index > 2 {
+		return nil, fmt.Errorf("appstore certificate index out of range")
+	}
+
+	parts := strings.Split(jwsRepresentation, ".")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("appstore invalid jws format")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("appstore failed to decode jws header: %w", err)
+	}
+
+	header := jwsHeader{}
+	if err = json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("appstore failed to unmarshal jws header: %w", err)
+	}
+
+	if len(header.X5C) <= index {
+		return nil, fmt.Errorf("appstore jws x5c chain missing certificate at index %d", index)
+	}
+
+	certBytes, err := base64.StdEncoding.DecodeString(header.X5C[index])
+	if err != nil {
+		return nil, fmt.Errorf("appstore failed to decode x5c certificate: %w", err)
+	}
+
+	return certBytes, nil
+}
+
+// trustedRoots returns the CertPool verifyCert chains against: Apple's published root CA plus any
+// roots configured on Cert.RootCertificates. The JWS-supplied root is intentionally never trusted
+// directly, since it is attacker-controlled.
+func (c *Cert) trustedRoots() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(AppleRootCAG3PEM)) {
+		return nil, fmt.Errorf("appstore failed to load embedded Apple root CA")
+	}
+
+	for _, pemBytes := range c.RootCertificates {
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("appstore failed to load configured root certificate")
+		}
+	}
+
+	return pool, nil
+}
+
+// verifyCert checks that leafCertificate chains to a pinned root via intermediateCertificate, and
+// that the leaf carries Apple's StoreKit signing EKU. The JWS-supplied rootCertificate is no
+// longer trusted as-is; it is accepted only for signature compatibility with callers that still
+// extract it from the x5c chain.
+func (c *Cert) verifyCert(_, intermediateCertificate, leafCertificate *x509.Certificate) error {
+	roots, err := c.trustedRoots()
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediateCertificate)
+
+	if _, err = leafCertificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+		// Apple's leaf certificate only asserts its own StoreKit EKU (checked below by
+		// verifyStoreKitEKU), not one of the standard EKUs x509.Verify requires by default.
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fmt.Errorf("appstore cert chain does not verify against a trusted root: %w", err)
+	}
+
+	return verifyStoreKitEKU(leafCertificate)
+}
+
+// VerifiedPublicKey extracts the root, intermediate, and leaf certificates from a JWS's x5c
+// chain, verifies the chain via verifyCert, and returns the leaf's public key. This is the single
+// chain-of-trust implementation shared by StoreClient.parseSignedTransaction and
+// NotificationHandler.verifiedPublicKey.
+func (c *Cert) VerifiedPublicKey(jwsRepresentation string) (*ecdsa.PublicKey, error) {
+	rootCertBytes, err := c.extractCertByIndex(jwsRepresentation, 2)
+	if err != nil {
+		return nil, err
+	}
+	rootCert, err := x509.ParseCertificate(rootCertBytes)
+	if err != nil {
+		return nil, fmt.Errorf("appstore failed to parse root certificate")
+	}
+
+	intermediaCertBytes, err := c.extractCertByIndex(jwsRepresentation, 1)
+	if err != nil {
+		return nil, err
+	}
+	intermediaCert, err := x509.ParseCertificate(intermediaCertBytes)
+	if err != nil {
+		return nil, fmt.Errorf("appstore failed to parse intermediate certificate")
+	}
+
+	leafCertBytes, err := c.extractCertByIndex(jwsRepresentation, 0)
+	if err != nil {
+		return nil, err
+	}
+	leafCert, err := x509.ParseCertificate(leafCertBytes)
+	if err != nil {
+		return nil, fmt.Errorf("appstore failed to parse leaf certificate")
+	}
+
+	if err = c.verifyCert(rootCert, intermediaCert, leafCert); err != nil {
+		return nil, err
+	}
+
+	pk, ok := leafCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("appstore public key must be of type ecdsa.PublicKey")
+	}
+
+	return pk, nil
+}
+
+func verifyStoreKitEKU(leaf *x509.Certificate) error {
+	for _, oid := range leaf.UnknownExtKeyUsage {
+		if oid.Equal(appleWWDRStoreKitEKU) {
+			return nil
+		}
+	}
+	return fmt.Errorf("appstore leaf certificate is missing the required StoreKit signing EKU")
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testChain builds a minimal self-signed root and a leaf signed by it, optionally carrying
+// Apple's StoreKit EKU, so verifyCert can be exercised without a real Apple-issued certificate.
+func testChain(t *testing.T, withStoreKitEKU bool) (rootPEM []byte, rootCert, leafCert *x509.Certificate) {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate root key: %v", err)
+	}
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("create root cert: %v", err)
+	}
+
+	rootCert, err = x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatalf("parse root cert: %v", err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate leaf key: %v", err)
+	}
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test Leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if withStoreKitEKU {
+		leafTemplate.UnknownExtKeyUsage = []asn1.ObjectIdentifier{appleWWDRStoreKitEKU}
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, rootCert, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("create leaf cert: %v", err)
+	}
+
+	leafCert, err = x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("parse leaf cert: %v", err)
+	}
+
+	rootPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER})
+
+	return rootPEM, rootCert, leafCert
+}
+
+func TestCertVerifyCertSucceedsAgainstConfiguredRoot(t *testing.T) {
+	rootPEM, rootCert, leafCert := testChain(t, true)
+	c := &Cert{RootCertificates: [][]byte{rootPEM}}
+
+	if err := c.verifyCert(rootCert, rootCert, leafCert); err != nil {
+		t.Fatalf("verifyCert returned an error for a chain anchored to a configured root: %v", err)
+	}
+}
+
+func TestCertVerifyCertRejectsUnconfiguredRoot(t *testing.T) {
+	_, rootCert, leafCert := testChain(t, true)
+	c := &Cert{} // root is never added to RootCertificates
+
+	if err := c.verifyCert(rootCert, rootCert, leafCert); err == nil {
+		t.Fatal("expected verifyCert to reject a chain anchored to an untrusted root")
+	}
+}
+
+func TestCertVerifyCertRejectsMissingStoreKitEKU(t *testing.T) {
+	rootPEM, rootCert, leafCert := testChain(t, false)
+	c := &Cert{RootCertificates: [][]byte{rootPEM}}
+
+	err := c.verifyCert(rootCert, rootCert, leafCert)
+	if err == nil {
+		t.Fatal("expected verifyCert to reject a leaf missing the StoreKit signing EKU")
+	}
+}
+
+func TestVerifyStoreKitEKU(t *testing.T) {
+	_, _, leafWithEKU := testChain(t, true)
+	if err := verifyStoreKitEKU(leafWithEKU); err != nil {
+		t.Errorf("verifyStoreKitEKU rejected a leaf carrying the StoreKit EKU: %v", err)
+	}
+
+	_, _, leafWithoutEKU := testChain(t, false)
+	if err := verifyStoreKitEKU(leafWithoutEKU); err == nil {
+		t.Error("verifyStoreKitEKU accepted a leaf missing the StoreKit EKU")
+	}
+}
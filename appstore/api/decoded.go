@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PathGetTransactionInfo is the endpoint for GetTransactionInfo.
+const PathGetTransactionInfo = "/inApps/v1/transactions/{transactionId}"
+
+// TransactionInfoResponse https://developer.apple.com/documentation/appstoreserverapi/transactioninforesponse
+type TransactionInfoResponse struct {
+	SignedTransactionInfo string `json:"signedTransactionInfo"`
+}
+
+// GetTransactionInfo https://developer.apple.com/documentation/appstoreserverapi/get_transaction_info
+func (a *StoreClient) GetTransactionInfo(ctx context.Context, transactionId string) (rsp *TransactionInfoResponse, err error) {
+	URL := HostProduction + PathGetTransactionInfo
+	if a.Token.Sandbox {
+		URL = HostSandBox + PathGetTransactionInfo
+	}
+	URL = strings.Replace(URL, "{transactionId}", transactionId, -1)
+
+	statusCode, body, err := a.Do(ctx, http.MethodGet, URL, nil)
+	if err != nil {
+		return
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("appstore api: %v return status code %v", URL, statusCode)
+	}
+
+	err = json.Unmarshal(body, &rsp)
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// DecodedHistoryResponse mirrors HistoryResponse with SignedTransactions already parsed into JWSTransaction values.
+type DecodedHistoryResponse struct {
+	*HistoryResponse
+	Transactions []*JWSTransaction
+}
+
+// GetTransactionHistoryDecoded behaves like GetTransactionHistory but additionally parses each
+// page's SignedTransactions into typed JWSTransaction values, saving callers a second pass over
+// ParseSignedTransactions.
+func (a *StoreClient) GetTransactionHistoryDecoded(ctx context.Context, originalTransactionId string, query *url.Values) ([]*DecodedHistoryResponse, error) {
+	responses, err := a.GetTransactionHistory(ctx, originalTransactionId, query)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make([]*DecodedHistoryResponse, 0, len(responses))
+	for _, rsp := range responses {
+		transactions, err := a.ParseSignedTransactions(rsp.SignedTransactions)
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, &DecodedHistoryResponse{HistoryResponse: rsp, Transactions: transactions})
+	}
+
+	return decoded, nil
+}
+
+// DecodedOrderLookupResponse mirrors OrderLookupResponse with SignedTransactions already parsed
+// into JWSTransaction values.
+type DecodedOrderLookupResponse struct {
+	*OrderLookupResponse
+	Transactions []*JWSTransaction
+}
+
+// LookupOrderIDDecoded behaves like LookupOrderID but additionally parses SignedTransactions into
+// typed JWSTransaction values.
+func (a *StoreClient) LookupOrderIDDecoded(ctx context.Context, orderId string) (*DecodedOrderLookupResponse, error) {
+	rsp, err := a.LookupOrderID(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := a.ParseSignedTransactions(rsp.SignedTransactions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecodedOrderLookupResponse{OrderLookupResponse: rsp, Transactions: transactions}, nil
+}
+
+// DecodedLastTransactionsItem mirrors LastTransactionsItem with its signed fields already parsed
+// into typed values.
+type DecodedLastTransactionsItem struct {
+	*LastTransactionsItem
+	Transaction *JWSTransaction
+	RenewalInfo *JWSRenewalInfo
+}
+
+// DecodedSubscriptionGroupIdentifierItem mirrors SubscriptionGroupIdentifierItem with its
+// LastTransactions decoded.
+type DecodedSubscriptionGroupIdentifierItem struct {
+	SubscriptionGroupIdentifier string
+	LastTransactions            []*DecodedLastTransactionsItem
+}
+
+// DecodedStatusResponse mirrors StatusResponse with every LastTransactionsItem's signed fields
+// already parsed into typed values.
+type DecodedStatusResponse struct {
+	*StatusResponse
+	Data []*DecodedSubscriptionGroupIdentifierItem
+}
+
+// GetALLSubscriptionStatusesDecoded behaves like GetALLSubscriptionStatuses but additionally
+// parses each subscription's SignedTransactionInfo and SignedRenewalInfo into typed values.
+func (a *StoreClient) GetALLSubscriptionStatusesDecoded(ctx context.Context, originalTransactionId string) (*DecodedStatusResponse, error) {
+	rsp, err := a.GetALLSubscriptionStatuses(ctx, originalTransactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &DecodedStatusResponse{StatusResponse: rsp, Data: make([]*DecodedSubscriptionGroupIdentifierItem, 0, len(rsp.Data))}
+	for _, group := range rsp.Data {
+		decodedGroup := &DecodedSubscriptionGroupIdentifierItem{
+			SubscriptionGroupIdentifier: group.SubscriptionGroupIdentifier,
+			LastTransactions:            make([]*DecodedLastTransactionsItem, 0, len(group.LastTransactions)),
+		}
+
+		for _, item := range group.LastTransactions {
+			item := item
+			decodedItem := &DecodedLastTransactionsItem{LastTransactionsItem: item}
+
+			if item.SignedTransactionInfo != "" {
+				decodedItem.Transaction, err = a.parseSignedTransaction(item.SignedTransactionInfo)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if item.SignedRenewalInfo != "" {
+				decodedItem.RenewalInfo, err = a.parseSignedRenewalInfo(item.SignedRenewalInfo)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			decodedGroup.LastTransactions = append(decodedGroup.LastTransactions, decodedItem)
+		}
+
+		decoded.Data = append(decoded.Data, decodedGroup)
+	}
+
+	return decoded, nil
+}
+
+func (a *StoreClient) parseSignedRenewalInfo(signed string) (*JWSRenewalInfo, error) {
+	return (&NotificationHandler{cert: a.cert}).parseSignedRenewalInfo(signed)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// redirectTransport rewrites every outgoing request to target base, so StoreClient methods that
+// hardcode HostProduction/HostSandBox into their URLs can still be pointed at an httptest.Server.
+type redirectTransport struct {
+	base *url.URL
+}
+
+func (rt *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.base.Scheme
+	req.URL.Host = rt.base.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// testStoreClientForServer builds a StoreClient whose requests are transparently redirected to
+// server, trusting rootPEM (if non-nil) alongside Apple's published root CA.
+func testStoreClientForServer(t *testing.T, server *httptest.Server, rootPEM []byte) *StoreClient {
+	t.Helper()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parse httptest server URL: %v", err)
+	}
+
+	var roots [][]byte
+	if rootPEM != nil {
+		roots = [][]byte{rootPEM}
+	}
+
+	return &StoreClient{
+		Token:   &Token{},
+		httpCli: &http.Client{Transport: &redirectTransport{base: base}},
+		cert:    &Cert{RootCertificates: roots},
+		retry:   DefaultRetryConfig(),
+	}
+}
+
+func TestGetTransactionInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "txn-123") {
+			t.Errorf("request path = %q, want it to contain transactionId", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(TransactionInfoResponse{SignedTransactionInfo: "signed-info"})
+	}))
+	defer server.Close()
+
+	a := testStoreClientForServer(t, server, nil)
+	rsp, err := a.GetTransactionInfo(context.Background(), "txn-123")
+	if err != nil {
+		t.Fatalf("GetTransactionInfo returned an error: %v", err)
+	}
+	if rsp.SignedTransactionInfo != "signed-info" {
+		t.Errorf("SignedTransactionInfo = %q, want %q", rsp.SignedTransactionInfo, "signed-info")
+	}
+}
+
+func TestGetTransactionHistoryDecodedDecodesTransactions(t *testing.T) {
+	rootPEM, rootCert, leafCert, leafKey := testNotificationChain(t)
+	signed := signNotificationPayload(t, leafKey, leafCert, rootCert, &JWSTransaction{TransactionId: "txn-1"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(HistoryResponse{SignedTransactions: []string{signed}, HasMore: false})
+	}))
+	defer server.Close()
+
+	a := testStoreClientForServer(t, server, rootPEM)
+	decoded, err := a.GetTransactionHistoryDecoded(context.Background(), "orig-txn", nil)
+	if err != nil {
+		t.Fatalf("GetTransactionHistoryDecoded returned an error: %v", err)
+	}
+	if len(decoded) != 1 || len(decoded[0].Transactions) != 1 {
+		t.Fatalf("GetTransactionHistoryDecoded = %+v, want one page with one decoded transaction", decoded)
+	}
+	if got := decoded[0].Transactions[0].TransactionId; got != "txn-1" {
+		t.Errorf("TransactionId = %q, want %q", got, "txn-1")
+	}
+}
+
+func TestGetTransactionHistoryDecodedPropagatesVerificationFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(HistoryResponse{SignedTransactions: []string{"not-a-jws"}, HasMore: false})
+	}))
+	defer server.Close()
+
+	a := testStoreClientForServer(t, server, nil)
+	if _, err := a.GetTransactionHistoryDecoded(context.Background(), "orig-txn", nil); err == nil {
+		t.Fatal("expected GetTransactionHistoryDecoded to propagate a signed-transaction verification failure")
+	}
+}
+
+func TestLookupOrderIDDecodedDecodesTransactions(t *testing.T) {
+	rootPEM, rootCert, leafCert, leafKey := testNotificationChain(t)
+	signed := signNotificationPayload(t, leafKey, leafCert, rootCert, &JWSTransaction{TransactionId: "txn-2"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OrderLookupResponse{SignedTransactions: []string{signed}})
+	}))
+	defer server.Close()
+
+	a := testStoreClientForServer(t, server, rootPEM)
+	decoded, err := a.LookupOrderIDDecoded(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("LookupOrderIDDecoded returned an error: %v", err)
+	}
+	if len(decoded.Transactions) != 1 || decoded.Transactions[0].TransactionId != "txn-2" {
+		t.Fatalf("LookupOrderIDDecoded.Transactions = %+v, want one decoded transaction with id txn-2", decoded.Transactions)
+	}
+}
+
+func TestLookupOrderIDDecodedPropagatesVerificationFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OrderLookupResponse{SignedTransactions: []string{"not-a-jws"}})
+	}))
+	defer server.Close()
+
+	a := testStoreClientForServer(t, server, nil)
+	if _, err := a.LookupOrderIDDecoded(context.Background(), "order-1"); err == nil {
+		t.Fatal("expected LookupOrderIDDecoded to propagate a signed-transaction verification failure")
+	}
+}
+
+func TestGetALLSubscriptionStatusesDecodedPropagatesVerificationFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(StatusResponse{Data: []*SubscriptionGroupIdentifierItem{
+			{
+				SubscriptionGroupIdentifier: "group-1",
+				LastTransactions: []*LastTransactionsItem{
+					{SignedTransactionInfo: "not-a-jws"},
+				},
+			},
+		}})
+	}))
+	defer server.Close()
+
+	a := testStoreClientForServer(t, server, nil)
+	if _, err := a.GetALLSubscriptionStatusesDecoded(context.Background(), "orig-txn"); err == nil {
+		t.Fatal("expected GetALLSubscriptionStatusesDecoded to propagate a signed-transaction verification failure")
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Done is returned by an iterator's Next method once there are no more pages to fetch.
+var Done = errors.New("appstore: no more pages")
+
+// TransactionHistoryIterator streams the pages of GetTransactionHistory one at a time, fetching
+// each page lazily on Next.
+type TransactionHistoryIterator struct {
+	a     *StoreClient
+	url   string
+	query *url.Values
+	done  bool
+}
+
+// IterTransactionHistory returns an iterator over GetTransactionHistory's pages, paging by
+// revision token, so callers with long-lived accounts can process transactions as they arrive
+// instead of waiting for every page to be fetched and held in memory up front.
+func (a *StoreClient) IterTransactionHistory(originalTransactionId string, query *url.Values) *TransactionHistoryIterator {
+	URL := HostProduction + PathTransactionHistory
+	if a.Token.Sandbox {
+		URL = HostSandBox + PathTransactionHistory
+	}
+	URL = strings.Replace(URL, "{originalTransactionId}", originalTransactionId, -1)
+
+	if query == nil {
+		query = &url.Values{}
+	}
+
+	return &TransactionHistoryIterator{a: a, url: URL, query: query}
+}
+
+// Next fetches the next page of transaction history, or returns Done once the history is
+// exhausted.
+func (it *TransactionHistoryIterator) Next(ctx context.Context) (*HistoryResponse, error) {
+	if it.done {
+		return nil, Done
+	}
+
+	statusCode, body, err := it.a.Do(ctx, http.MethodGet, it.url+"?"+it.query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("appstore api: %v return status code %v", it.url, statusCode)
+	}
+
+	rsp := &HistoryResponse{}
+	if err = json.Unmarshal(body, rsp); err != nil {
+		return nil, err
+	}
+
+	it.done = !rsp.HasMore
+	if rsp.HasMore && rsp.Revision != "" {
+		it.query.Set("revision", rsp.Revision)
+	}
+
+	return rsp, nil
+}
+
+// RefundHistoryIterator streams the pages of GetRefundHistory one at a time, fetching each page
+// lazily on Next.
+type RefundHistoryIterator struct {
+	a       *StoreClient
+	baseURL string
+	url     string
+	done    bool
+}
+
+// IterRefundHistory returns an iterator over GetRefundHistory's pages, paging by revision token,
+// so callers can stop early once they've seen the refunds they care about instead of always
+// walking the account's full refund history.
+func (a *StoreClient) IterRefundHistory(originalTransactionId string) *RefundHistoryIterator {
+	baseURL := HostProduction + PathRefundHistory
+	if a.Token.Sandbox {
+		baseURL = HostSandBox + PathRefundHistory
+	}
+	baseURL = strings.Replace(baseURL, "{originalTransactionId}", originalTransactionId, -1)
+
+	return &RefundHistoryIterator{a: a, baseURL: baseURL, url: baseURL}
+}
+
+// Next fetches the next page of refund history, or returns Done once the history is exhausted.
+func (it *RefundHistoryIterator) Next(ctx context.Context) (*RefundLookupResponse, error) {
+	if it.done {
+		return nil, Done
+	}
+
+	statusCode, body, err := it.a.Do(ctx, http.MethodGet, it.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("appstore api: %v return status code %v", it.url, statusCode)
+	}
+
+	rsp := &RefundLookupResponse{}
+	if err = json.Unmarshal(body, rsp); err != nil {
+		return nil, err
+	}
+
+	it.done = !rsp.HasMore
+	if rsp.HasMore && rsp.Revision != "" {
+		data := url.Values{}
+		data.Set("revision", rsp.Revision)
+		it.url = it.baseURL + "?" + data.Encode()
+	}
+
+	return rsp, nil
+}
+
+// NotificationHistoryIterator streams the pages of GetNotificationHistory one at a time, fetching
+// each page lazily on Next.
+type NotificationHistoryIterator struct {
+	a       *StoreClient
+	baseURL string
+	url     string
+	body    []byte
+	done    bool
+}
+
+// IterNotificationHistory returns an iterator over GetNotificationHistory's pages, paging by
+// paginationToken, so callers replaying a wide startDate/endDate window can start acting on
+// notifications as each page arrives instead of waiting for the whole window to be fetched.
+func (a *StoreClient) IterNotificationHistory(body NotificationHistoryRequest) (*NotificationHistoryIterator, error) {
+	baseURL := HostProduction + PathGetNotificationHistory
+	if a.Token.Sandbox {
+		baseURL = HostSandBox + PathGetNotificationHistory
+	}
+
+	bodyBuf := new(bytes.Buffer)
+	if err := json.NewEncoder(bodyBuf).Encode(body); err != nil {
+		return nil, err
+	}
+
+	return &NotificationHistoryIterator{a: a, baseURL: baseURL, url: baseURL, body: bodyBuf.Bytes()}, nil
+}
+
+// Next fetches the next page of notification history, or returns Done once the history is
+// exhausted.
+func (it *NotificationHistoryIterator) Next(ctx context.Context) (*NotificationHistoryResponses, error) {
+	if it.done {
+		return nil, Done
+	}
+
+	statusCode, body, err := it.a.Do(ctx, http.MethodPost, it.url, bytes.NewReader(it.body))
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("appstore api: %v return status code %v", it.url, statusCode)
+	}
+
+	rsp := &NotificationHistoryResponses{}
+	rsp.NotificationHistory = make([]NotificationHistoryResponseItem, 0)
+	if err = json.Unmarshal(body, rsp); err != nil {
+		return nil, err
+	}
+
+	it.done = !rsp.HasMore
+	if rsp.HasMore && rsp.PaginationToken != "" {
+		data := url.Values{}
+		data.Set("paginationToken", rsp.PaginationToken)
+		it.url = it.baseURL + "?" + data.Encode()
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// NotificationTypeV2 is the notificationType field of a version 2 App Store Server Notification.
+// Per doc: https://developer.apple.com/documentation/appstoreservernotifications/notificationtype
+type NotificationTypeV2 string
+
+const (
+	NotificationTypeV2ConsumptionRequest     NotificationTypeV2 = "CONSUMPTION_REQUEST"
+	NotificationTypeV2DidChangeRenewalPref   NotificationTypeV2 = "DID_CHANGE_RENEWAL_PREF"
+	NotificationTypeV2DidChangeRenewalStatus NotificationTypeV2 = "DID_CHANGE_RENEWAL_STATUS"
+	NotificationTypeV2DidFailToRenew         NotificationTypeV2 = "DID_FAIL_TO_RENEW"
+	NotificationTypeV2DidRenew               NotificationTypeV2 = "DID_RENEW"
+	NotificationTypeV2Expired                NotificationTypeV2 = "EXPIRED"
+	NotificationTypeV2GracePeriodExpired     NotificationTypeV2 = "GRACE_PERIOD_EXPIRED"
+	NotificationTypeV2OfferRedeemed          NotificationTypeV2 = "OFFER_REDEEMED"
+	NotificationTypeV2PriceIncrease          NotificationTypeV2 = "PRICE_INCREASE"
+	NotificationTypeV2Refund                 NotificationTypeV2 = "REFUND"
+	NotificationTypeV2RefundDeclined         NotificationTypeV2 = "REFUND_DECLINED"
+	NotificationTypeV2RefundReversed         NotificationTypeV2 = "REFUND_REVERSED"
+	NotificationTypeV2RenewalExtended        NotificationTypeV2 = "RENEWAL_EXTENDED"
+	NotificationTypeV2RenewalExtension       NotificationTypeV2 = "RENEWAL_EXTENSION"
+	NotificationTypeV2Revoke                 NotificationTypeV2 = "REVOKE"
+	NotificationTypeV2Subscribed             NotificationTypeV2 = "SUBSCRIBED"
+	NotificationTypeV2Test                   NotificationTypeV2 = "TEST"
+)
+
+// SubtypeV2 is the subtype field that refines a NotificationTypeV2.
+// Per doc: https://developer.apple.com/documentation/appstoreservernotifications/subtype
+type SubtypeV2 string
+
+const (
+	SubtypeV2InitialBuy        SubtypeV2 = "INITIAL_BUY"
+	SubtypeV2Resubscribe       SubtypeV2 = "RESUBSCRIBE"
+	SubtypeV2Downgrade         SubtypeV2 = "DOWNGRADE"
+	SubtypeV2Upgrade           SubtypeV2 = "UPGRADE"
+	SubtypeV2AutoRenewEnabled  SubtypeV2 = "AUTO_RENEW_ENABLED"
+	SubtypeV2AutoRenewDisabled SubtypeV2 = "AUTO_RENEW_DISABLED"
+	SubtypeV2Voluntary         SubtypeV2 = "VOLUNTARY"
+	SubtypeV2BillingRetry      SubtypeV2 = "BILLING_RETRY"
+	SubtypeV2PriceIncrease     SubtypeV2 = "PRICE_INCREASE"
+	SubtypeV2GracePeriod       SubtypeV2 = "GRACE_PERIOD"
+	SubtypeV2BillingRecovery   SubtypeV2 = "BILLING_RECOVERY"
+	SubtypeV2Pending           SubtypeV2 = "PENDING"
+	SubtypeV2Accepted          SubtypeV2 = "ACCEPTED"
+)
+
+// ResponseBodyV2 is the envelope Apple POSTs to a merchant's App Store Server Notifications V2 webhook.
+// Per doc: https://developer.apple.com/documentation/appstoreservernotifications/responsebodyv2
+type ResponseBodyV2 struct {
+	SignedPayload string `json:"signedPayload"`
+}
+
+// ResponseBodyV2DecodedPayload is the decoded JWS payload of a ResponseBodyV2, with the nested
+// signedTransactionInfo / signedRenewalInfo JWS fields already verified and decoded.
+// Per doc: https://developer.apple.com/documentation/appstoreservernotifications/responsebodyv2decodedpayload
+type ResponseBodyV2DecodedPayload struct {
+	NotificationType    NotificationTypeV2   `json:"notificationType"`
+	Subtype             SubtypeV2            `json:"subtype"`
+	NotificationUUID    string               `json:"notificationUUID"`
+	NotificationVersion string               `json:"version"`
+	Data                *NotificationData    `json:"data,omitempty"`
+	Summary             *NotificationSummary `json:"summary,omitempty"`
+}
+
+// Valid satisfies jwt.Claims so the payload can be the target of jwt.ParseWithClaims.
+func (p *ResponseBodyV2DecodedPayload) Valid() error {
+	return nil
+}
+
+// NotificationData carries the transaction/renewal context of a notification.
+// Per doc: https://developer.apple.com/documentation/appstoreservernotifications/data
+type NotificationData struct {
+	AppAppleID             int64           `json:"appAppleId"`
+	BundleID               string          `json:"bundleId"`
+	BundleVersion          string          `json:"bundleVersion"`
+	Environment            string          `json:"environment"`
+	SignedTransactionInfo  string          `json:"signedTransactionInfo"`
+	SignedRenewalInfo      string          `json:"signedRenewalInfo"`
+	Status                 int32           `json:"status"`
+	DecodedTransactionInfo *JWSTransaction `json:"-"`
+	DecodedRenewalInfo     *JWSRenewalInfo `json:"-"`
+}
+
+// NotificationSummary is present on summary notifications such as RENEWAL_EXTENSION.
+// Per doc: https://developer.apple.com/documentation/appstoreservernotifications/summary
+type NotificationSummary struct {
+	RequestIdentifier      string   `json:"requestIdentifier"`
+	Environment            string   `json:"environment"`
+	AppAppleID             int64    `json:"appAppleId"`
+	BundleID               string   `json:"bundleId"`
+	ProductID              string   `json:"productId"`
+	StorefrontCountryCodes []string `json:"storefrontCountryCodes"`
+	FailedCount            int64    `json:"failedCount"`
+	SucceededCount         int64    `json:"succeededCount"`
+}
+
+// JWSRenewalInfo is the decoded form of a signedRenewalInfo JWS field.
+// Per doc: https://developer.apple.com/documentation/appstoreserverapi/jwsrenewalinfodecodedpayload
+type JWSRenewalInfo struct {
+	AutoRenewProductId          string `json:"autoRenewProductId"`
+	AutoRenewStatus             int32  `json:"autoRenewStatus"`
+	Environment                 string `json:"environment"`
+	ExpirationIntent            int32  `json:"expirationIntent"`
+	GracePeriodExpiresDate      int64  `json:"gracePeriodExpiresDate"`
+	IsInBillingRetryPeriod      bool   `json:"isInBillingRetryPeriod"`
+	OfferIdentifier             string `json:"offerIdentifier"`
+	OfferType                   int32  `json:"offerType"`
+	OriginalTransactionId       string `json:"originalTransactionId"`
+	PriceIncreaseStatus         int32  `json:"priceIncreaseStatus"`
+	ProductId                   string `json:"productId"`
+	RecentSubscriptionStartDate int64  `json:"recentSubscriptionStartDate"`
+	RenewalDate                 int64  `json:"renewalDate"`
+	SignedDate                  int64  `json:"signedDate"`
+}
+
+// Valid satisfies jwt.Claims so the renewal info can be the target of jwt.ParseWithClaims.
+func (i *JWSRenewalInfo) Valid() error {
+	return nil
+}
+
+// NotificationHandler verifies and decodes App Store Server Notifications V2 payloads using the
+// same JWS chain-of-trust machinery as StoreClient.ParseSignedTransactions.
+type NotificationHandler struct {
+	cert *Cert
+}
+
+// NewNotificationHandler creates a handler for verifying and decoding incoming V2 notifications.
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{cert: &Cert{}}
+}
+
+// NewNotificationHandlerWithRootCertificates creates a handler that additionally trusts the given
+// PEM-encoded root certificates, alongside Apple's published root CA, when verifying the x5c chain
+// of incoming notifications.
+func NewNotificationHandlerWithRootCertificates(rootCertificates [][]byte) *NotificationHandler {
+	return &NotificationHandler{cert: &Cert{RootCertificates: rootCertificates}}
+}
+
+// ParseNotificationV2 verifies the JWS chain of a raw signedPayload body Apple POSTs to a merchant
+// webhook and returns the fully decoded payload, including any nested signedTransactionInfo and
+// signedRenewalInfo JWS fields.
+func (n *NotificationHandler) ParseNotificationV2(signedPayload string) (*ResponseBodyV2DecodedPayload, error) {
+	payload := &ResponseBodyV2DecodedPayload{}
+
+	pk, err := n.verifiedPublicKey(signedPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = jwt.ParseWithClaims(signedPayload, payload, func(token *jwt.Token) (interface{}, error) {
+		return pk, nil
+	}); err != nil {
+		return nil, fmt.Errorf("appstore parse notification payload err %w", err)
+	}
+
+	if payload.Data == nil {
+		return payload, nil
+	}
+
+	if payload.Data.SignedTransactionInfo != "" {
+		if payload.Data.DecodedTransactionInfo, err = n.parseSignedTransactionInfo(payload.Data.SignedTransactionInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	if payload.Data.SignedRenewalInfo != "" {
+		if payload.Data.DecodedRenewalInfo, err = n.parseSignedRenewalInfo(payload.Data.SignedRenewalInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	return payload, nil
+}
+
+func (n *NotificationHandler) parseSignedTransactionInfo(signed string) (*JWSTransaction, error) {
+	tran := &JWSTransaction{}
+	pk, err := n.verifiedPublicKey(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = jwt.ParseWithClaims(signed, tran, func(token *jwt.Token) (interface{}, error) {
+		return pk, nil
+	}); err != nil {
+		return nil, fmt.Errorf("appstore parse signed transaction info err %w", err)
+	}
+
+	return tran, nil
+}
+
+func (n *NotificationHandler) parseSignedRenewalInfo(signed string) (*JWSRenewalInfo, error) {
+	info := &JWSRenewalInfo{}
+	pk, err := n.verifiedPublicKey(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = jwt.ParseWithClaims(signed, info, func(token *jwt.Token) (interface{}, error) {
+		return pk, nil
+	}); err != nil {
+		return nil, fmt.Errorf("appstore parse signed renewal info err %w", err)
+	}
+
+	return info, nil
+}
+
+// verifiedPublicKey extracts and verifies the x5c chain of a JWS the same way
+// StoreClient.parseSignedTransaction does, returning the leaf's public key.
+func (n *NotificationHandler) verifiedPublicKey(jwsRepresentation string) (*ecdsa.PublicKey, error) {
+	return n.cert.VerifiedPublicKey(jwsRepresentation)
+}
+
+// NotificationServer adapts a NotificationHandler into an http.Handler so it can be mounted
+// directly as a merchant's App Store Server Notifications V2 webhook.
+type NotificationServer struct {
+	Handler *NotificationHandler
+
+	// OnNotification is invoked with the decoded payload of every verified notification. If it
+	// returns an error, the webhook responds with a non-2xx status so Apple retries delivery.
+	OnNotification func(ctx context.Context, payload *ResponseBodyV2DecodedPayload) error
+}
+
+func (s *NotificationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "appstore: failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	envelope := ResponseBodyV2{}
+	if err = json.Unmarshal(body, &envelope); err != nil {
+		http.Error(w, "appstore: malformed notification body", http.StatusBadRequest)
+		return
+	}
+
+	payload, err := s.Handler.ParseNotificationV2(envelope.SignedPayload)
+	if err != nil {
+		http.Error(w, "appstore: invalid notification signature", http.StatusBadRequest)
+		return
+	}
+
+	if s.OnNotification != nil {
+		if err = s.OnNotification(r.Context(), payload); err != nil {
+			http.Error(w, "appstore: notification handling failed", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
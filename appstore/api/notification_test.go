@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// testNotificationChain builds a self-signed root and a StoreKit-EKU leaf signed by it, returning
+// the leaf's private key so callers can sign a JWS with it. The root is reused as the
+// "intermediate" slot of the x5c chain, mirroring how cert_test.go's verifyCert tests pass the
+// same certificate for both positions.
+func testNotificationChain(t *testing.T) (rootPEM []byte, rootCert, leafCert *x509.Certificate, leafKey *ecdsa.PrivateKey) {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate root key: %v", err)
+	}
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Notification Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("create root cert: %v", err)
+	}
+
+	rootCert, err = x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatalf("parse root cert: %v", err)
+	}
+
+	leafKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate leaf key: %v", err)
+	}
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber:       big.NewInt(2),
+		Subject:            pkix.Name{CommonName: "Test Notification Leaf"},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{appleWWDRStoreKitEKU},
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, rootCert, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("create leaf cert: %v", err)
+	}
+
+	leafCert, err = x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("parse leaf cert: %v", err)
+	}
+
+	rootPEM = pemEncodeCert(rootDER)
+
+	return rootPEM, rootCert, leafCert, leafKey
+}
+
+func pemEncodeCert(der []byte) []byte {
+	return []byte("-----BEGIN CERTIFICATE-----\n" + base64.StdEncoding.EncodeToString(der) + "\n-----END CERTIFICATE-----\n")
+}
+
+// signNotificationPayload builds a JWS carrying the given claims and an x5c chain of
+// [leaf, root, root], signed with leafKey, the same shape ParseNotificationV2 expects.
+func signNotificationPayload(t *testing.T, leafKey *ecdsa.PrivateKey, leafCert, rootCert *x509.Certificate, claims jwt.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["x5c"] = []string{
+		base64.StdEncoding.EncodeToString(leafCert.Raw),
+		base64.StdEncoding.EncodeToString(rootCert.Raw),
+		base64.StdEncoding.EncodeToString(rootCert.Raw),
+	}
+
+	signed, err := token.SignedString(leafKey)
+	if err != nil {
+		t.Fatalf("sign notification payload: %v", err)
+	}
+
+	return signed
+}
+
+func TestNotificationHandlerParseNotificationV2Succeeds(t *testing.T) {
+	rootPEM, rootCert, leafCert, leafKey := testNotificationChain(t)
+	n := NewNotificationHandlerWithRootCertificates([][]byte{rootPEM})
+
+	claims := &ResponseBodyV2DecodedPayload{
+		NotificationType: NotificationTypeV2Subscribed,
+		Subtype:          SubtypeV2InitialBuy,
+		NotificationUUID: "11111111-1111-1111-1111-111111111111",
+	}
+	signed := signNotificationPayload(t, leafKey, leafCert, rootCert, claims)
+
+	payload, err := n.ParseNotificationV2(signed)
+	if err != nil {
+		t.Fatalf("ParseNotificationV2 returned an error for a chain anchored to a configured root: %v", err)
+	}
+	if payload.NotificationType != NotificationTypeV2Subscribed {
+		t.Errorf("NotificationType = %q, want %q", payload.NotificationType, NotificationTypeV2Subscribed)
+	}
+	if payload.Subtype != SubtypeV2InitialBuy {
+		t.Errorf("Subtype = %q, want %q", payload.Subtype, SubtypeV2InitialBuy)
+	}
+}
+
+func TestNotificationHandlerParseNotificationV2RejectsUntrustedRoot(t *testing.T) {
+	_, rootCert, leafCert, leafKey := testNotificationChain(t)
+	n := NewNotificationHandler() // root is never configured as trusted
+
+	claims := &ResponseBodyV2DecodedPayload{NotificationType: NotificationTypeV2Test}
+	signed := signNotificationPayload(t, leafKey, leafCert, rootCert, claims)
+
+	if _, err := n.ParseNotificationV2(signed); err == nil {
+		t.Fatal("expected ParseNotificationV2 to reject a chain anchored to an untrusted root")
+	}
+}
+
+func TestNotificationServerServeHTTP(t *testing.T) {
+	rootPEM, rootCert, leafCert, leafKey := testNotificationChain(t)
+
+	claims := &ResponseBodyV2DecodedPayload{NotificationType: NotificationTypeV2DidRenew}
+	signed := signNotificationPayload(t, leafKey, leafCert, rootCert, claims)
+
+	var received *ResponseBodyV2DecodedPayload
+	server := &NotificationServer{
+		Handler: NewNotificationHandlerWithRootCertificates([][]byte{rootPEM}),
+		OnNotification: func(_ context.Context, payload *ResponseBodyV2DecodedPayload) error {
+			received = payload
+			return nil
+		},
+	}
+
+	envelope, err := json.Marshal(ResponseBodyV2{SignedPayload: signed})
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(envelope)))
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if received == nil || received.NotificationType != NotificationTypeV2DidRenew {
+		t.Fatalf("OnNotification did not receive the decoded payload: %+v", received)
+	}
+}
+
+func TestNotificationServerServeHTTPRejectsMalformedBody(t *testing.T) {
+	server := &NotificationServer{Handler: NewNotificationHandler()}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not json"))
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ServeHTTP status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestNotificationServerServeHTTPRejectsInvalidSignature(t *testing.T) {
+	_, rootCert, leafCert, leafKey := testNotificationChain(t)
+	server := &NotificationServer{Handler: NewNotificationHandler()} // root is never configured as trusted
+
+	claims := &ResponseBodyV2DecodedPayload{NotificationType: NotificationTypeV2Test}
+	signed := signNotificationPayload(t, leafKey, leafCert, rootCert, claims)
+
+	envelope, err := json.Marshal(ResponseBodyV2{SignedPayload: signed})
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(envelope)))
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ServeHTTP status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
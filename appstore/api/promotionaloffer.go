@@ -0,0 +1,54 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// promotionalOfferFieldSeparator is the invisible U+2063 separator Apple requires between each
+// field of the promotional offer signature payload.
+const promotionalOfferFieldSeparator = "⁣"
+
+// GeneratePromotionalOfferSignature signs a promotional offer with the same p8 key already loaded
+// in StoreConfig, producing the base64-encoded signature StoreKit's SKPaymentDiscount expects.
+// Per doc: https://developer.apple.com/documentation/storekit/in-app_purchase/original_api_for_in-app_purchase/generating_a_signature_for_promotional_offers
+func (a *StoreClient) GeneratePromotionalOfferSignature(productID, offerID string, appAccountToken, nonce uuid.UUID, timestamp int64) (string, error) {
+	privateKey, err := jwt.ParseECPrivateKeyFromPEM(a.config.KeyContent)
+	if err != nil {
+		return "", fmt.Errorf("appstore failed to parse private key: %w", err)
+	}
+
+	payload := strings.Join([]string{
+		a.config.BundleID,
+		a.config.KeyID,
+		productID,
+		offerID,
+		appAccountToken.String(),
+		nonce.String(),
+		strconv.FormatInt(timestamp, 10),
+	}, promotionalOfferFieldSeparator)
+
+	digest := sha256.Sum256([]byte(payload))
+
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("appstore failed to sign promotional offer: %w", err)
+	}
+
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return "", fmt.Errorf("appstore failed to encode promotional offer signature: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
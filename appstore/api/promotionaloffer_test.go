@@ -0,0 +1,100 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testStoreClient(t *testing.T) (*StoreClient, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	return &StoreClient{config: &StoreConfig{
+		KeyContent: keyPEM,
+		KeyID:      "2X9R4HXF34",
+		BundleID:   "com.example.app",
+	}}, key
+}
+
+func TestGeneratePromotionalOfferSignature(t *testing.T) {
+	a, key := testStoreClient(t)
+
+	productID := "com.example.product"
+	offerID := "intro2023"
+	appAccountToken := uuid.New()
+	nonce := uuid.New()
+	timestamp := int64(1700000000000)
+
+	sig, err := a.GeneratePromotionalOfferSignature(productID, offerID, appAccountToken, nonce, timestamp)
+	if err != nil {
+		t.Fatalf("GeneratePromotionalOfferSignature returned an error: %v", err)
+	}
+
+	derSig, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		t.Fatalf("signature is not valid base64: %v", err)
+	}
+
+	var parsed struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(derSig, &parsed); err != nil {
+		t.Fatalf("signature is not valid ASN.1 DER: %v", err)
+	}
+
+	payload := strings.Join([]string{
+		a.config.BundleID,
+		a.config.KeyID,
+		productID,
+		offerID,
+		appAccountToken.String(),
+		nonce.String(),
+		strconv.FormatInt(timestamp, 10),
+	}, promotionalOfferFieldSeparator)
+	digest := sha256.Sum256([]byte(payload))
+
+	if !ecdsa.Verify(&key.PublicKey, digest[:], parsed.R, parsed.S) {
+		t.Fatal("signature does not verify against the signing key and expected payload")
+	}
+}
+
+func TestGeneratePromotionalOfferSignatureVariesWithInput(t *testing.T) {
+	a, _ := testStoreClient(t)
+
+	appAccountToken := uuid.New()
+	nonce := uuid.New()
+
+	sig1, err := a.GeneratePromotionalOfferSignature("product.a", "offer.a", appAccountToken, nonce, 1)
+	if err != nil {
+		t.Fatalf("GeneratePromotionalOfferSignature returned an error: %v", err)
+	}
+
+	sig2, err := a.GeneratePromotionalOfferSignature("product.b", "offer.a", appAccountToken, nonce, 1)
+	if err != nil {
+		t.Fatalf("GeneratePromotionalOfferSignature returned an error: %v", err)
+	}
+
+	if sig1 == sig2 {
+		t.Fatal("expected signatures for different productIDs to differ")
+	}
+}
@@ -3,14 +3,15 @@ package api
 import (
 	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -36,12 +37,78 @@ type StoreConfig struct {
 	BundleID   string // Your app’s bundle ID
 	Issuer     string // Your issuer ID from the Keys page in App Store Connect (Ex: "57246542-96fe-1a63-e053-0824d011072a")
 	Sandbox    bool   // default is Production
+
+	Retry       *RetryConfig // optional, DefaultRetryConfig() is used when nil
+	RateLimiter RateLimiter  // optional, no rate limiting is applied when nil
+
+	// RootCertificates are additional PEM-encoded roots trusted when verifying the x5c chain of
+	// signed transactions and notifications, alongside Apple's published root CA.
+	RootCertificates [][]byte
+}
+
+// RetryConfig controls how StoreClient.Do retries transient failures against Apple's Server API.
+type RetryConfig struct {
+	MaxAttempts     int           // total attempts including the first; <= 1 disables retries
+	InitialDelay    time.Duration // delay before the first retry
+	MaxDelay        time.Duration // exponential delay is capped at this value before jitter is applied
+	Jitter          float64       // fraction of the computed delay to randomize, e.g. 0.2 for +/-20%
+	RetryableStatus map[int]bool  // status codes that should be retried; defaults below when nil
+}
+
+// DefaultRetryConfig returns the RetryConfig used when StoreConfig.Retry is nil.
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxAttempts:  4,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		Jitter:       0.2,
+		RetryableStatus: map[int]bool{
+			http.StatusRequestTimeout:      true,
+			http.StatusTooManyRequests:     true,
+			http.StatusInternalServerError: true,
+			http.StatusBadGateway:          true,
+			http.StatusServiceUnavailable:  true,
+			http.StatusGatewayTimeout:      true,
+		},
+	}
+}
+
+func (r *RetryConfig) isRetryableStatus(statusCode int) bool {
+	if r.RetryableStatus == nil {
+		return DefaultRetryConfig().RetryableStatus[statusCode]
+	}
+	return r.RetryableStatus[statusCode]
+}
+
+// backoff returns the delay before retry attempt n (1-indexed): the exponential delay is capped at
+// MaxDelay and then jitter is applied, so the result can exceed MaxDelay by up to the Jitter fraction.
+func (r *RetryConfig) backoff(attempt int) time.Duration {
+	delay := float64(r.InitialDelay) * math.Pow(2, float64(attempt-1))
+	if max := float64(r.MaxDelay); r.MaxDelay > 0 && delay > max {
+		delay = max
+	}
+	if r.Jitter > 0 {
+		delay += delay * r.Jitter * (rand.Float64()*2 - 1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// RateLimiter throttles outgoing requests before they are sent. It is satisfied by
+// *golang.org/x/time/rate.Limiter, among others.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
 }
 
 type StoreClient struct {
 	Token   *Token
 	httpCli *http.Client
 	cert    *Cert
+	config  *StoreConfig
+	retry   *RetryConfig
+	limiter RateLimiter
 }
 
 // NewStoreClient create a appstore server api client
@@ -51,10 +118,13 @@ func NewStoreClient(config *StoreConfig) *StoreClient {
 
 	client := &StoreClient{
 		Token: token,
-		cert:  &Cert{},
+		cert:  &Cert{RootCertificates: config.RootCertificates},
 		httpCli: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		config:  config,
+		retry:   retryConfigOrDefault(config),
+		limiter: config.RateLimiter,
 	}
 	return client
 }
@@ -66,12 +136,22 @@ func NewStoreClientWithHTTPClient(config *StoreConfig, httpClient *http.Client)
 
 	client := &StoreClient{
 		Token:   token,
-		cert:    &Cert{},
+		cert:    &Cert{RootCertificates: config.RootCertificates},
 		httpCli: httpClient,
+		config:  config,
+		retry:   retryConfigOrDefault(config),
+		limiter: config.RateLimiter,
 	}
 	return client
 }
 
+func retryConfigOrDefault(config *StoreConfig) *RetryConfig {
+	if config.Retry != nil {
+		return config.Retry
+	}
+	return DefaultRetryConfig()
+}
+
 // GetALLSubscriptionStatuses https://developer.apple.com/documentation/appstoreserverapi/get_all_subscription_statuses
 func (a *StoreClient) GetALLSubscriptionStatuses(ctx context.Context, originalTransactionId string) (rsp *StatusResponse, err error) {
 	URL := HostProduction + PathGetALLSubscriptionStatus
@@ -122,88 +202,36 @@ func (a *StoreClient) LookupOrderID(ctx context.Context, orderId string) (rsp *O
 
 // GetTransactionHistory https://developer.apple.com/documentation/appstoreserverapi/get_transaction_history
 func (a *StoreClient) GetTransactionHistory(ctx context.Context, originalTransactionId string, query *url.Values) (responses []*HistoryResponse, err error) {
-	URL := HostProduction + PathTransactionHistory
-	if a.Token.Sandbox {
-		URL = HostSandBox + PathTransactionHistory
-	}
-	URL = strings.Replace(URL, "{originalTransactionId}", originalTransactionId, -1)
-
-	if query == nil {
-		query = &url.Values{}
-	}
-
+	it := a.IterTransactionHistory(originalTransactionId, query)
 	for {
-		rsp := HistoryResponse{}
-
-		statusCode, body, errOmit := a.Do(ctx, http.MethodGet, URL+"?"+query.Encode(), nil)
-		if errOmit != nil {
-			return nil, errOmit
+		rsp, err := it.Next(ctx)
+		if err == Done {
+			return responses, nil
 		}
-
-		if statusCode != http.StatusOK {
-			return nil, fmt.Errorf("appstore api: %v return status code %v", URL, statusCode)
-		}
-
-		err = json.Unmarshal(body, &rsp)
 		if err != nil {
 			return nil, err
 		}
 
-		responses = append(responses, &rsp)
-		if !rsp.HasMore {
-			break
-		}
-
-		if rsp.HasMore && rsp.Revision != "" {
-			query.Set("revision", rsp.Revision)
-		}
-
+		responses = append(responses, rsp)
 		time.Sleep(10 * time.Millisecond)
 	}
-
-	return
 }
 
 // GetRefundHistory https://developer.apple.com/documentation/appstoreserverapi/get_refund_history
 func (a *StoreClient) GetRefundHistory(ctx context.Context, originalTransactionId string) (responses []*RefundLookupResponse, err error) {
-	baseURL := HostProduction + PathRefundHistory
-	if a.Token.Sandbox {
-		baseURL = HostSandBox + PathRefundHistory
-	}
-	baseURL = strings.Replace(baseURL, "{originalTransactionId}", originalTransactionId, -1)
-
-	URL := baseURL
+	it := a.IterRefundHistory(originalTransactionId)
 	for {
-		rsp := RefundLookupResponse{}
-
-		statusCode, body, errOmit := a.Do(ctx, http.MethodGet, URL, nil)
-		if errOmit != nil {
-			return nil, errOmit
+		rsp, err := it.Next(ctx)
+		if err == Done {
+			return responses, nil
 		}
-
-		if statusCode != http.StatusOK {
-			return nil, fmt.Errorf("appstore api: %v return status code %v", URL, statusCode)
-		}
-
-		err = json.Unmarshal(body, &rsp)
 		if err != nil {
 			return nil, err
 		}
 
-		responses = append(responses, &rsp)
-		if !rsp.HasMore {
-			break
-		}
-
-		data := url.Values{}
-		if rsp.HasMore && rsp.Revision != "" {
-			data.Set("revision", rsp.Revision)
-			URL = baseURL + "?" + data.Encode()
-		}
-
+		responses = append(responses, rsp)
 		time.Sleep(10 * time.Millisecond)
 	}
-	return
 }
 
 // SendConsumptionInfo https://developer.apple.com/documentation/appstoreserverapi/send_consumption_information
@@ -251,51 +279,23 @@ func (a *StoreClient) ExtendSubscriptionRenewalDate(ctx context.Context, origina
 // GetNotificationHistory https://developer.apple.com/documentation/appstoreserverapi/get_notification_history
 // Note: Notification history is available starting on June 6, 2022. Use a startDate of June 6, 2022 or later in your request.
 func (a *StoreClient) GetNotificationHistory(ctx context.Context, body NotificationHistoryRequest) (responses []NotificationHistoryResponseItem, err error) {
-	baseURL := HostProduction + PathGetNotificationHistory
-	if a.Token.Sandbox {
-		baseURL = HostSandBox + PathGetNotificationHistory
-	}
-
-	bodyBuf := new(bytes.Buffer)
-	err = json.NewEncoder(bodyBuf).Encode(body)
+	it, err := a.IterNotificationHistory(body)
 	if err != nil {
 		return nil, err
 	}
 
-	URL := baseURL
 	for {
-		rsp := NotificationHistoryResponses{}
-		rsp.NotificationHistory = make([]NotificationHistoryResponseItem, 0)
-
-		statusCode, rspBody, errOmit := a.Do(ctx, http.MethodPost, URL, bodyBuf)
-		if errOmit != nil {
-			return nil, errOmit
+		rsp, err := it.Next(ctx)
+		if err == Done {
+			return responses, nil
 		}
-
-		if statusCode != http.StatusOK {
-			return nil, fmt.Errorf("appstore api: %v return status code %v", URL, statusCode)
-		}
-
-		err = json.Unmarshal(rspBody, &rsp)
 		if err != nil {
 			return nil, err
 		}
 
 		responses = append(responses, rsp.NotificationHistory...)
-		if !rsp.HasMore {
-			break
-		}
-
-		data := url.Values{}
-		if rsp.HasMore && rsp.PaginationToken != "" {
-			data.Set("paginationToken", rsp.PaginationToken)
-			URL = baseURL + "?" + data.Encode()
-		}
-
 		time.Sleep(10 * time.Millisecond)
 	}
-
-	return responses, nil
 }
 
 // SendRequestTestNotification https://developer.apple.com/documentation/appstoreserverapi/request_a_test_notification
@@ -322,12 +322,13 @@ func (a *StoreClient) GetTestNotificationStatus(ctx context.Context, testNotific
 // ParseSignedTransactions parse the jws singed transactions
 // Per doc: https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.6
 func (a *StoreClient) ParseSignedTransactions(transactions []string) ([]*JWSTransaction, error) {
-	result := make([]*JWSTransaction, 0)
+	result := make([]*JWSTransaction, 0, len(transactions))
 	for _, v := range transactions {
 		trans, err := a.parseSignedTransaction(v)
-		if err == nil && trans != nil {
-			result = append(result, trans)
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, trans)
 	}
 
 	return result, nil
@@ -336,61 +337,91 @@ func (a *StoreClient) ParseSignedTransactions(transactions []string) ([]*JWSTran
 func (a *StoreClient) parseSignedTransaction(transaction string) (*JWSTransaction, error) {
 	tran := &JWSTransaction{}
 
-	rootCertBytes, err := a.cert.extractCertByIndex(transaction, 2)
+	pk, err := a.cert.VerifiedPublicKey(transaction)
 	if err != nil {
 		return nil, err
 	}
-	rootCert, err := x509.ParseCertificate(rootCertBytes)
-	if err != nil {
-		return nil, fmt.Errorf("appstore failed to parse root certificate")
-	}
 
-	intermediaCertBytes, err := a.cert.extractCertByIndex(transaction, 1)
+	_, err = jwt.ParseWithClaims(transaction, tran, func(token *jwt.Token) (interface{}, error) {
+		return pk, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	intermediaCert, err := x509.ParseCertificate(intermediaCertBytes)
-	if err != nil {
-		return nil, fmt.Errorf("appstore failed to parse intermediate certificate")
-	}
 
-	leafCertBytes, err := a.cert.extractCertByIndex(transaction, 0)
-	if err != nil {
-		return nil, err
-	}
-	leafCert, err := x509.ParseCertificate(leafCertBytes)
-	if err != nil {
-		return nil, fmt.Errorf("appstore failed to parse leaf certificate")
+	return tran, nil
+}
+
+// Per doc: https://developer.apple.com/documentation/appstoreserverapi#topics
+func (a *StoreClient) Do(ctx context.Context, method string, url string, body io.Reader) (int, []byte, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = io.ReadAll(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("appstore read request body err %w", err)
+		}
 	}
-	if err = a.cert.verifyCert(rootCert, intermediaCert, leafCert); err != nil {
-		return nil, err
+
+	retry := a.retry
+	if retry == nil {
+		retry = DefaultRetryConfig()
 	}
 
-	pk, ok := leafCert.PublicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("appstore public key must be of type ecdsa.PublicKey")
+	maxAttempts := retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
 	}
 
-	_, err = jwt.ParseWithClaims(transaction, tran, func(token *jwt.Token) (interface{}, error) {
-		return pk, nil
-	})
-	if err != nil {
-		return nil, err
+	var statusCode int
+	var respBody []byte
+	var doErr error
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if a.limiter != nil {
+			if err := a.limiter.Wait(ctx); err != nil {
+				return 0, nil, fmt.Errorf("appstore rate limiter wait err %w", err)
+			}
+		}
+
+		var bodyReader io.Reader
+		if bodyBytes != nil {
+			bodyReader = bytes.NewReader(bodyBytes)
+		}
+
+		var retryAfter time.Duration
+		statusCode, respBody, doErr, retryAfter = a.doOnce(ctx, method, url, bodyReader)
+
+		retryable := doErr != nil || retry.isRetryableStatus(statusCode)
+		if !retryable || attempt == maxAttempts {
+			return statusCode, respBody, doErr
+		}
+
+		delay := retry.backoff(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return statusCode, respBody, ctx.Err()
+		case <-time.After(delay):
+		}
 	}
 
-	return tran, nil
+	return statusCode, respBody, doErr
 }
 
-// Per doc: https://developer.apple.com/documentation/appstoreserverapi#topics
-func (a *StoreClient) Do(ctx context.Context, method string, url string, body io.Reader) (int, []byte, error) {
+// doOnce performs a single HTTP attempt and returns the parsed Retry-After delay, if any.
+func (a *StoreClient) doOnce(ctx context.Context, method string, url string, body io.Reader) (int, []byte, error, time.Duration) {
 	authToken, err := a.Token.GenerateIfExpired()
 	if err != nil {
-		return 0, nil, fmt.Errorf("appstore generate token err %w", err)
+		return 0, nil, fmt.Errorf("appstore generate token err %w", err), 0
 	}
 
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return 0, nil, fmt.Errorf("appstore new http request err %w", err)
+		return 0, nil, fmt.Errorf("appstore new http request err %w", err), 0
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -400,14 +431,27 @@ func (a *StoreClient) Do(ctx context.Context, method string, url string, body io
 
 	resp, err := a.httpCli.Do(req)
 	if err != nil {
-		return 0, nil, fmt.Errorf("appstore http client do err %w", err)
+		return 0, nil, fmt.Errorf("appstore http client do err %w", err), 0
 	}
 	defer resp.Body.Close()
 
-	bytes, err := io.ReadAll(resp.Body)
+	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return resp.StatusCode, nil, fmt.Errorf("appstore read http body err %w", err)
+		return resp.StatusCode, nil, fmt.Errorf("appstore read http body err %w", err), 0
 	}
 
-	return resp.StatusCode, bytes, err
+	return resp.StatusCode, respBytes, nil, retryAfterDelay(resp.Header.Get("Retry-After"))
+}
+
+func retryAfterDelay(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryConfigBackoff(t *testing.T) {
+	r := &RetryConfig{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     1 * time.Second,
+		Jitter:       0,
+	}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, 1 * time.Second}, // capped at MaxDelay
+	}
+
+	for _, c := range cases {
+		if got := r.backoff(c.attempt); got != c.want {
+			t.Errorf("backoff(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestRetryConfigBackoffJitterStaysNonNegative(t *testing.T) {
+	r := &RetryConfig{
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     0,
+		Jitter:       1,
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := r.backoff(1); got < 0 {
+			t.Fatalf("backoff returned negative duration: %v", got)
+		}
+	}
+}
+
+func TestRetryConfigIsRetryableStatus(t *testing.T) {
+	r := DefaultRetryConfig()
+
+	if !r.isRetryableStatus(http.StatusTooManyRequests) {
+		t.Error("expected 429 to be retryable by default")
+	}
+	if r.isRetryableStatus(http.StatusOK) {
+		t.Error("expected 200 not to be retryable")
+	}
+
+	custom := &RetryConfig{RetryableStatus: map[int]bool{http.StatusOK: true}}
+	if !custom.isRetryableStatus(http.StatusOK) {
+		t.Error("expected custom RetryableStatus to override the default table")
+	}
+	if custom.isRetryableStatus(http.StatusTooManyRequests) {
+		t.Error("expected status codes missing from a custom RetryableStatus to be non-retryable")
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	if got := retryAfterDelay(""); got != 0 {
+		t.Errorf("retryAfterDelay(\"\") = %v, want 0", got)
+	}
+
+	if got := retryAfterDelay("5"); got != 5*time.Second {
+		t.Errorf("retryAfterDelay(\"5\") = %v, want 5s", got)
+	}
+
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	got := retryAfterDelay(future)
+	if got <= 0 || got > 10*time.Second {
+		t.Errorf("retryAfterDelay(%q) = %v, want a positive duration close to 10s", future, got)
+	}
+
+	if got := retryAfterDelay("not-a-delay"); got != 0 {
+		t.Errorf("retryAfterDelay(garbage) = %v, want 0", got)
+	}
+}